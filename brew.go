@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// brewCellarPaths covers both the Intel Homebrew prefix and the Apple
+// Silicon one; a host may only have one, or (rarely) both.
+var brewCellarPaths = []string{"/usr/local/Cellar", "/opt/homebrew/Cellar"}
+
+// brewInstallReceipt is the subset of Homebrew's INSTALL_RECEIPT.json we
+// care about. It's written by brew itself for every installed keg, unlike
+// the formula Ruby file, which may be missing once a formula is removed
+// from the tap.
+type brewInstallReceipt struct {
+	Source struct {
+		Spec     string            `json:"spec"`
+		Versions map[string]string `json:"versions"`
+	} `json:"source"`
+	Time                int64 `json:"time"`
+	InstalledOnRequest  bool  `json:"installed_on_request"`
+	RuntimeDependencies []struct {
+		FullName string `json:"full_name"`
+	} `json:"runtime_dependencies"`
+}
+
+func listBrewPackages() ([]Package, error) {
+	var packages []Package
+	found := false
+	for _, cellarPath := range brewCellarPaths {
+		if _, err := os.Stat(cellarPath); err != nil {
+			continue
+		}
+		found = true
+		pkgs, err := listBrewPackagesAt(cellarPath)
+		if err != nil {
+			return nil, err
+		}
+		packages = append(packages, pkgs...)
+	}
+	if !found {
+		return nil, fmt.Errorf("Homebrew cellar not found in any of %v", brewCellarPaths)
+	}
+	return packages, nil
+}
+
+func listBrewPackagesAt(cellarPath string) ([]Package, error) {
+	cellarInfo, err := os.Stat(cellarPath)
+	if err != nil {
+		return nil, fmt.Errorf("Homebrew cellar not found in %s: %v", cellarPath, err)
+	}
+	if !cellarInfo.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", cellarPath)
+	}
+
+	packageDirs, err := ioutil.ReadDir(cellarPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading directory %s: %v", cellarPath, err)
+	}
+
+	packages := []Package{}
+	for _, packageDir := range packageDirs {
+		if !packageDir.IsDir() {
+			continue
+		}
+		pkgPath := path.Join(cellarPath, packageDir.Name())
+		versions, err := ioutil.ReadDir(pkgPath)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading directory: %s: %v", pkgPath, err)
+		}
+		for _, version := range versions {
+			if !version.IsDir() {
+				continue
+			}
+			pkg := Package{
+				Name:        packageDir.Name(),
+				Version:     version.Name(),
+				InstallTime: version.ModTime(),
+			}
+			applyBrewReceipt(&pkg, path.Join(pkgPath, version.Name(), "INSTALL_RECEIPT.json"))
+			applyBrewFormulaMeta(&pkg)
+			packages = append(packages, pkg)
+		}
+	}
+	return packages, nil
+}
+
+// applyBrewReceipt fills in fields that only INSTALL_RECEIPT.json knows
+// about: the exact installed version, install time, and runtime
+// dependencies. A keg without a receipt (very old brew installs) keeps the
+// defaults already set from the Cellar path.
+func applyBrewReceipt(pkg *Package, receiptPath string) {
+	data, err := ioutil.ReadFile(receiptPath)
+	if err != nil {
+		return
+	}
+	var receipt brewInstallReceipt
+	if err := json.Unmarshal(data, &receipt); err != nil {
+		return
+	}
+
+	if v, ok := receipt.Source.Versions[receipt.Source.Spec]; ok && v != "" {
+		pkg.Version = v
+	}
+	if receipt.Time > 0 {
+		pkg.InstallTime = time.Unix(receipt.Time, 0)
+	}
+	for _, dep := range receipt.RuntimeDependencies {
+		pkg.Dependencies = append(pkg.Dependencies, dep.FullName)
+	}
+}
+
+// applyBrewFormulaMeta fills in Summary, URL and License from brew's
+// locally cached formula API index, which covers formulas whose on-disk
+// .rb file is missing or hard to scrape.
+func applyBrewFormulaMeta(pkg *Package) {
+	index, err := brewFormulaIndex()
+	if err != nil {
+		return
+	}
+	meta, ok := index[pkg.Name]
+	if !ok {
+		return
+	}
+	pkg.Summary = meta.Desc
+	pkg.URL = meta.Homepage
+	pkg.License = meta.License
+}
+
+type brewFormulaMeta struct {
+	Name     string `json:"name"`
+	Desc     string `json:"desc"`
+	Homepage string `json:"homepage"`
+	License  string `json:"license"`
+}
+
+// brewFormulaJWS is the shape of $(brew --cache)/api/formula.jws.json: a
+// JWS envelope whose payload is a JSON-encoded array of formulas.
+type brewFormulaJWS struct {
+	Payload string `json:"payload"`
+}
+
+var (
+	brewFormulaIndexMu  sync.Mutex
+	brewFormulaIndexVal map[string]brewFormulaMeta
+)
+
+// brewFormulaIndex lazily loads and caches the formula.jws.json index,
+// since it can be tens of megabytes and every Cellar package would
+// otherwise trigger a re-read. A failed load is not cached, so transient
+// errors (e.g. brew not yet on PATH during --watch startup) can succeed on
+// a later call instead of disabling enrichment for the rest of the run.
+func brewFormulaIndex() (map[string]brewFormulaMeta, error) {
+	brewFormulaIndexMu.Lock()
+	defer brewFormulaIndexMu.Unlock()
+
+	if brewFormulaIndexVal != nil {
+		return brewFormulaIndexVal, nil
+	}
+	index, err := loadBrewFormulaIndex()
+	if err != nil {
+		return nil, err
+	}
+	brewFormulaIndexVal = index
+	return brewFormulaIndexVal, nil
+}
+
+func loadBrewFormulaIndex() (map[string]brewFormulaMeta, error) {
+	out, err := exec.Command("brew", "--cache").Output()
+	if err != nil {
+		return nil, fmt.Errorf("Error running 'brew --cache': %v", err)
+	}
+	jwsPath := path.Join(strings.TrimSpace(string(out)), "api", "formula.jws.json")
+
+	data, err := ioutil.ReadFile(jwsPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %s: %v", jwsPath, err)
+	}
+
+	var jws brewFormulaJWS
+	if err := json.Unmarshal(data, &jws); err != nil {
+		return nil, fmt.Errorf("Error parsing %s: %v", jwsPath, err)
+	}
+
+	var formulas []brewFormulaMeta
+	if err := json.Unmarshal([]byte(jws.Payload), &formulas); err != nil {
+		return nil, fmt.Errorf("Error parsing formula.jws.json payload: %v", err)
+	}
+
+	index := make(map[string]brewFormulaMeta, len(formulas))
+	for _, f := range formulas {
+		index[f.Name] = f
+	}
+	return index, nil
+}
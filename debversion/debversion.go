@@ -0,0 +1,175 @@
+// Package debversion implements comparison of Debian package versions, as
+// used by dpkg and described in the Debian Policy Manual section 5.6.12.
+//
+// A version has the form [epoch:]upstream-version[-debian-revision]. Epochs
+// compare numerically, and upstream-version/debian-revision each compare by
+// walking alternating non-digit/digit segments, with '~' sorting before
+// everything else (even the empty string), so "1.0~beta1" < "1.0".
+package debversion
+
+import "strconv"
+
+// Compare returns -1, 0 or 1 depending on whether Debian version a sorts
+// before, equal to, or after b.
+func Compare(a, b string) int {
+	epochA, upstreamA, revisionA := split(a)
+	epochB, upstreamB, revisionB := split(b)
+
+	if c := compareEpoch(epochA, epochB); c != 0 {
+		return c
+	}
+	if c := compareSegments(upstreamA, upstreamB); c != 0 {
+		return c
+	}
+	return compareSegments(revisionA, revisionB)
+}
+
+// Satisfies reports whether version v satisfies the relation "v op target",
+// where op is one of the Debian policy relational operators: <<, <=, =,
+// >=, >>.
+func Satisfies(v, op, target string) bool {
+	c := Compare(v, target)
+	switch op {
+	case "<<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case "=":
+		return c == 0
+	case ">=":
+		return c >= 0
+	case ">>":
+		return c > 0
+	default:
+		return false
+	}
+}
+
+// split breaks a version into its epoch, upstream-version and
+// debian-revision parts. A missing epoch defaults to "0"; a missing
+// debian-revision defaults to "0", per Debian policy.
+func split(version string) (epoch, upstream, revision string) {
+	epoch = "0"
+	rest := version
+	for i := 0; i < len(version); i++ {
+		if version[i] == ':' {
+			epoch = version[:i]
+			rest = version[i+1:]
+			break
+		}
+	}
+
+	upstream = rest
+	revision = "0"
+	for i := len(rest) - 1; i >= 0; i-- {
+		if rest[i] == '-' {
+			upstream = rest[:i]
+			revision = rest[i+1:]
+			break
+		}
+	}
+	return epoch, upstream, revision
+}
+
+func compareEpoch(a, b string) int {
+	na, _ := strconv.Atoi(a)
+	nb, _ := strconv.Atoi(b)
+	switch {
+	case na < nb:
+		return -1
+	case na > nb:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareSegments implements dpkg's verrevcmp: walk alternating runs of
+// non-digits and digits, comparing each pair of runs in turn.
+func compareSegments(a, b string) int {
+	ia, ib := 0, 0
+	for ia < len(a) || ib < len(b) {
+		// Compare a run of non-digits.
+		for (ia < len(a) && !isDigit(a[ia])) || (ib < len(b) && !isDigit(b[ib])) {
+			var ac, bc int
+			if ia < len(a) {
+				ac = charOrder(a[ia])
+			}
+			if ib < len(b) {
+				bc = charOrder(b[ib])
+			}
+			if ac != bc {
+				return sign(ac - bc)
+			}
+			if ia < len(a) {
+				ia++
+			}
+			if ib < len(b) {
+				ib++
+			}
+		}
+
+		// Skip leading zeros, then compare a run of digits by length first
+		// (a longer run of digits is numerically larger), then value.
+		for ia < len(a) && a[ia] == '0' {
+			ia++
+		}
+		for ib < len(b) && b[ib] == '0' {
+			ib++
+		}
+		startA, startB := ia, ib
+		for ia < len(a) && isDigit(a[ia]) {
+			ia++
+		}
+		for ib < len(b) && isDigit(b[ib]) {
+			ib++
+		}
+		if lenA, lenB := ia-startA, ib-startB; lenA != lenB {
+			return sign(lenA - lenB)
+		}
+		if a[startA:ia] != b[startB:ib] {
+			if a[startA:ia] < b[startB:ib] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// charOrder ranks a byte for comparison within a non-digit run: '~' sorts
+// before everything (including the end of the string, represented by 0),
+// then digits and the end of the string (both rank 0), then letters, then
+// everything else in ASCII order. charOrder is called on a digit whenever
+// one side has run out of non-digits and the other hasn't yet.
+func charOrder(c byte) int {
+	switch {
+	case c == '~':
+		return -1
+	case isDigit(c):
+		return 0
+	case isAlpha(c):
+		return int(c)
+	default:
+		return int(c) + 256
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,53 @@
+package debversion
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.1", -1},
+		{"1.1", "1.0", 1},
+		{"1.0-1", "1.0-2", -1},
+		{"1:1.0", "2.0", 1},
+		{"1.0~beta1", "1.0", -1},
+		{"1.0", "1.0~beta1", 1},
+		{"1.0~~", "1.0~", -1},
+		{"1.0.1", "1.0.a", -1},
+		{"1.0.a", "1.0.1", 1},
+		{"1a", "aa", -1},
+		{"1.0", "1.0.1", -1},
+		{"1.001", "1.1", 0},
+		{"1.0-a", "1.0-b", -1},
+	}
+
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		v, op, target string
+		want          bool
+	}{
+		{"1.1.1", ">>", "1.1.0", true},
+		{"1.1.1", ">>", "1.1.1", false},
+		{"1.1.1", ">=", "1.1.1", true},
+		{"1.1.0", "<<", "1.1.1", true},
+		{"1.1.1", "<<", "1.1.1", false},
+		{"1.1.1", "<=", "1.1.1", true},
+		{"1.1.1", "=", "1.1.1", true},
+		{"1.1.1", "=", "1.1.2", false},
+	}
+
+	for _, tt := range tests {
+		if got := Satisfies(tt.v, tt.op, tt.target); got != tt.want {
+			t.Errorf("Satisfies(%q, %q, %q) = %v, want %v", tt.v, tt.op, tt.target, got, tt.want)
+		}
+	}
+}
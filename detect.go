@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// pathExists reports whether path exists, regardless of type.
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// packageSource pairs a package manager with the paths that indicate it's
+// present on this host and the lister that enumerates its packages.
+type packageSource struct {
+	Name   string
+	Paths  []string
+	Lister func() ([]Package, error)
+}
+
+// packageSources lists every package manager this tool knows how to detect
+// and enumerate. A host is probed for each one instead of switching on its
+// OS family, so e.g. a Debian base image with Homebrew-on-Linux installed
+// gets both inventoried.
+var packageSources = []packageSource{
+	{Name: "dpkg", Paths: []string{"/var/lib/dpkg/status"}, Lister: listDebPackages},
+	{Name: "rpm", Paths: []string{"/var/lib/rpm/Packages", "/var/lib/rpm/rpmdb.sqlite"}, Lister: listRPMPackages},
+	{Name: "brew", Paths: brewCellarPaths, Lister: listBrewPackages},
+	{Name: "pacman", Paths: []string{pacmanLocalDir}, Lister: listPacmanPackages},
+	{Name: "portage", Paths: []string{portageDBDir}, Lister: listPortagePackages},
+}
+
+// detectPackageManagers probes packageSources for the ones present on this
+// host, runs each applicable lister, and merges the results, tagging every
+// Package with the Source that produced it.
+func detectPackageManagers() ([]Package, error) {
+	var packages []Package
+	var errs []error
+	detected := false
+
+	for _, src := range packageSources {
+		present := false
+		for _, p := range src.Paths {
+			if pathExists(p) {
+				present = true
+				break
+			}
+		}
+		if !present {
+			continue
+		}
+		detected = true
+
+		pkgs, err := src.Lister()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", src.Name, err))
+			continue
+		}
+		for i := range pkgs {
+			pkgs[i].Source = src.Name
+		}
+		packages = append(packages, pkgs...)
+	}
+
+	if !detected {
+		return nil, fmt.Errorf("No known package manager directories found on this host")
+	}
+	if len(errs) > 0 {
+		return packages, fmt.Errorf("Some listers failed: %v", errs)
+	}
+	return packages, nil
+}
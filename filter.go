@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tsg/listpackages/debversion"
+)
+
+// whereClause is a single "field op value" term from a --where expression,
+// e.g. "version>>1.1.1" or "name=openssl".
+type whereClause struct {
+	field string
+	op    string
+	value string
+}
+
+// twoCharWhereOps are tried before the single-character "=" so that e.g.
+// ">=" isn't mistaken for "=".
+var twoCharWhereOps = []string{"<<", "<=", ">=", ">>"}
+
+// parseWhere parses a --where expression such as
+// "name=openssl,version>>1.1.1" into its comma-separated clauses.
+func parseWhere(expr string) ([]whereClause, error) {
+	var clauses []whereClause
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		op, idx := "", -1
+		for _, candidate := range twoCharWhereOps {
+			if i := strings.Index(part, candidate); i >= 0 {
+				op, idx = candidate, i
+				break
+			}
+		}
+		if op == "" {
+			if i := strings.Index(part, "="); i >= 0 {
+				op, idx = "=", i
+			}
+		}
+		if op == "" {
+			return nil, fmt.Errorf("Invalid --where clause (no operator found): %q", part)
+		}
+
+		clauses = append(clauses, whereClause{
+			field: strings.TrimSpace(part[:idx]),
+			op:    op,
+			value: strings.TrimSpace(part[idx+len(op):]),
+		})
+	}
+	return clauses, nil
+}
+
+// matchesWhere reports whether pkg satisfies a single clause. "name" only
+// supports exact match; "version" supports the full set of Debian policy
+// relational operators via debversion.
+func matchesWhere(pkg Package, c whereClause) (bool, error) {
+	switch c.field {
+	case "name":
+		if c.op != "=" {
+			return false, fmt.Errorf("--where field %q only supports '='", c.field)
+		}
+		return pkg.Name == c.value, nil
+	case "version":
+		return debversion.Satisfies(pkg.Version, c.op, c.value), nil
+	default:
+		return false, fmt.Errorf("Unknown --where field %q", c.field)
+	}
+}
+
+// filterPackages returns the subset of packages matching every clause.
+func filterPackages(packages []Package, clauses []whereClause) ([]Package, error) {
+	filtered := []Package{}
+	for _, pkg := range packages {
+		matched := true
+		for _, c := range clauses {
+			ok, err := matchesWhere(pkg, c)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			filtered = append(filtered, pkg)
+		}
+	}
+	return filtered, nil
+}
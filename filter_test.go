@@ -0,0 +1,153 @@
+package main
+
+import "testing"
+
+func TestParseWhereMultipleClauses(t *testing.T) {
+	clauses, err := parseWhere("name=openssl,version>>1.1.1")
+	if err != nil {
+		t.Fatalf("parseWhere: %v", err)
+	}
+	if len(clauses) != 2 {
+		t.Fatalf("got %d clauses, want 2: %+v", len(clauses), clauses)
+	}
+	if clauses[0] != (whereClause{field: "name", op: "=", value: "openssl"}) {
+		t.Errorf("clauses[0] = %+v, want {name = openssl}", clauses[0])
+	}
+	if clauses[1] != (whereClause{field: "version", op: ">>", value: "1.1.1"}) {
+		t.Errorf("clauses[1] = %+v, want {version >> 1.1.1}", clauses[1])
+	}
+}
+
+func TestParseWhereOperators(t *testing.T) {
+	for _, op := range []string{"<<", "<=", "=", ">=", ">>"} {
+		clauses, err := parseWhere("version" + op + "1.0")
+		if err != nil {
+			t.Fatalf("parseWhere(%q): %v", op, err)
+		}
+		if len(clauses) != 1 || clauses[0].op != op || clauses[0].value != "1.0" {
+			t.Errorf("parseWhere(%q) = %+v, want op %q value %q", op, clauses, op, "1.0")
+		}
+	}
+}
+
+func TestParseWhereTrimsWhitespace(t *testing.T) {
+	clauses, err := parseWhere(" name = openssl , version >= 1.0 ")
+	if err != nil {
+		t.Fatalf("parseWhere: %v", err)
+	}
+	if len(clauses) != 2 {
+		t.Fatalf("got %d clauses, want 2: %+v", len(clauses), clauses)
+	}
+	if clauses[0] != (whereClause{field: "name", op: "=", value: "openssl"}) {
+		t.Errorf("clauses[0] = %+v, want {name = openssl}", clauses[0])
+	}
+	if clauses[1] != (whereClause{field: "version", op: ">=", value: "1.0"}) {
+		t.Errorf("clauses[1] = %+v, want {version >= 1.0}", clauses[1])
+	}
+}
+
+func TestParseWhereSkipsEmptyClauses(t *testing.T) {
+	clauses, err := parseWhere("name=openssl,,")
+	if err != nil {
+		t.Fatalf("parseWhere: %v", err)
+	}
+	if len(clauses) != 1 {
+		t.Fatalf("got %d clauses, want 1: %+v", len(clauses), clauses)
+	}
+}
+
+func TestParseWhereNoOperator(t *testing.T) {
+	if _, err := parseWhere("name"); err == nil {
+		t.Fatal("expected an error for a clause with no operator, got nil")
+	}
+}
+
+func TestParseWhereGreaterThanIsNotMistakenForEquals(t *testing.T) {
+	clauses, err := parseWhere("version>>1.1.1")
+	if err != nil {
+		t.Fatalf("parseWhere: %v", err)
+	}
+	if len(clauses) != 1 || clauses[0].op != ">>" {
+		t.Fatalf("got %+v, want a single >> clause", clauses)
+	}
+}
+
+func TestMatchesWhereName(t *testing.T) {
+	pkg := Package{Name: "openssl", Version: "1.1.1n"}
+
+	ok, err := matchesWhere(pkg, whereClause{field: "name", op: "=", value: "openssl"})
+	if err != nil || !ok {
+		t.Errorf("matchesWhere name=openssl = %v, %v, want true, nil", ok, err)
+	}
+
+	ok, err = matchesWhere(pkg, whereClause{field: "name", op: "=", value: "bash"})
+	if err != nil || ok {
+		t.Errorf("matchesWhere name=bash = %v, %v, want false, nil", ok, err)
+	}
+
+	if _, err := matchesWhere(pkg, whereClause{field: "name", op: ">>", value: "openssl"}); err == nil {
+		t.Error("expected an error for name with a non-'=' operator, got nil")
+	}
+}
+
+func TestMatchesWhereVersion(t *testing.T) {
+	pkg := Package{Name: "openssl", Version: "1.1.1n"}
+
+	ok, err := matchesWhere(pkg, whereClause{field: "version", op: ">>", value: "1.1.1"})
+	if err != nil || !ok {
+		t.Errorf("matchesWhere version>>1.1.1 = %v, %v, want true, nil", ok, err)
+	}
+
+	ok, err = matchesWhere(pkg, whereClause{field: "version", op: "<<", value: "1.1.1"})
+	if err != nil || ok {
+		t.Errorf("matchesWhere version<<1.1.1 = %v, %v, want false, nil", ok, err)
+	}
+}
+
+func TestMatchesWhereUnknownField(t *testing.T) {
+	if _, err := matchesWhere(Package{}, whereClause{field: "size", op: "=", value: "1"}); err == nil {
+		t.Fatal("expected an error for an unknown --where field, got nil")
+	}
+}
+
+func TestFilterPackages(t *testing.T) {
+	packages := []Package{
+		{Name: "openssl", Version: "1.1.1n"},
+		{Name: "openssl", Version: "1.0.2"},
+		{Name: "bash", Version: "5.1"},
+	}
+
+	clauses, err := parseWhere("name=openssl,version>>1.1.0")
+	if err != nil {
+		t.Fatalf("parseWhere: %v", err)
+	}
+
+	filtered, err := filterPackages(packages, clauses)
+	if err != nil {
+		t.Fatalf("filterPackages: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].Version != "1.1.1n" {
+		t.Fatalf("got %+v, want just openssl 1.1.1n", filtered)
+	}
+}
+
+func TestFilterPackagesNoClausesMatchesEverything(t *testing.T) {
+	packages := []Package{{Name: "openssl"}, {Name: "bash"}}
+
+	filtered, err := filterPackages(packages, nil)
+	if err != nil {
+		t.Fatalf("filterPackages: %v", err)
+	}
+	if len(filtered) != 2 {
+		t.Fatalf("got %d packages, want 2", len(filtered))
+	}
+}
+
+func TestFilterPackagesPropagatesMatchError(t *testing.T) {
+	packages := []Package{{Name: "openssl"}}
+	clauses := []whereClause{{field: "bogus", op: "=", value: "x"}}
+
+	if _, err := filterPackages(packages, clauses); err == nil {
+		t.Fatal("expected an error for an unknown field, got nil")
+	}
+}
@@ -3,27 +3,29 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
-	"path"
 	"strconv"
 	"strings"
 	"time"
-
-	sysinfo "github.com/elastic/go-sysinfo"
 )
 
+var watchInterval = flag.Duration("watch", 0, "if set, poll for package changes at this interval and emit installed/removed/updated events as JSON lines (e.g. --watch 30s) instead of printing a one-shot snapshot")
+var whereExpr = flag.String("where", "", "only print packages matching this expression, e.g. --where 'name=openssl,version>>1.1.1'")
+
 type Package struct {
-	Name        string
-	Version     string
-	Release     string
-	Arch        string
-	License     string
-	InstallTime time.Time
-	Size        uint64
-	Summary     string
-	URL         string
+	Name         string
+	Version      string
+	Release      string
+	Arch         string
+	License      string
+	InstallTime  time.Time
+	Size         uint64
+	Summary      string
+	URL          string
+	Source       string
+	Dependencies []string
 }
 
 func listDebPackages() ([]Package, error) {
@@ -78,109 +80,48 @@ func listDebPackages() ([]Package, error) {
 	return packages, nil
 }
 
-func listBrewPackages() ([]Package, error) {
-	cellarPath := "/usr/local/Cellar"
+func main() {
 
-	cellarInfo, err := os.Stat(cellarPath)
-	if err != nil {
-		return nil, fmt.Errorf("Homebrew cellar not found in %s: %v", cellarPath, err)
-	}
-	if !cellarInfo.IsDir() {
-		return nil, fmt.Errorf("%s is not a directory", cellarPath)
+	if len(os.Args) > 1 && os.Args[1] == rpmWorkerFlag {
+		runRPMWorker()
+		return
 	}
 
-	packageDirs, err := ioutil.ReadDir(cellarPath)
-	if err != nil {
-		return nil, fmt.Errorf("Error reading directory %s: %v", cellarPath, err)
-	}
+	flag.Parse()
 
-	packages := []Package{}
-	for _, packageDir := range packageDirs {
-		if !packageDir.IsDir() {
-			continue
-		}
-		pkgPath := path.Join(cellarPath, packageDir.Name())
-		versions, err := ioutil.ReadDir(pkgPath)
-		if err != nil {
-			return nil, fmt.Errorf("Error reading directory: %s: %v", pkgPath, err)
-		}
-		for _, version := range versions {
-			if !version.IsDir() {
-				continue
-			}
-			pkg := Package{
-				Name:        packageDir.Name(),
-				Version:     version.Name(),
-				InstallTime: version.ModTime(),
-			}
+	lister := detectPackageManagers
 
-			// read formula
-			formulaPath := path.Join(cellarPath, pkg.Name, pkg.Version, ".brew", pkg.Name+".rb")
-			file, err := os.Open(formulaPath)
-			if err != nil {
-				//fmt.Printf("WARNING: Can't get formula for package %s-%s\n", pkg.Name, pkg.Version)
-				// TODO: follow the path from INSTALL_RECEIPT.json to find the formula
-				continue
-			}
-			scanner := bufio.NewScanner(file)
-			count := 15 // only look into the first few lines of the formula
-			for scanner.Scan() {
-				count -= 1
-				if count == 0 {
-					break
-				}
-				line := scanner.Text()
-				if strings.HasPrefix(line, "  desc ") {
-					pkg.Summary = strings.Trim(line[7:], " \"")
-				} else if strings.HasPrefix(line, "  homepage ") {
-					pkg.URL = strings.Trim(line[11:], " \"")
-				}
-			}
-
-			packages = append(packages, pkg)
+	if *watchInterval > 0 {
+		w := NewWatcher(*watchInterval, "listpackages-state.json", lister)
+		if err := w.Run(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
 		}
+		return
 	}
-	return packages, nil
-}
-
-func main() {
 
-	host, err := sysinfo.Host()
+	packages, err := lister()
 	if err != nil {
-		fmt.Println("Error getting the OS: %v", err)
-		os.Exit(1)
-	}
-
-	hostInfo := host.Info()
-	if hostInfo.OS == nil {
-		fmt.Println("No OS info from sysinfo.Host")
-		os.Exit(1)
-	}
-
-	var packages []Package
-	switch hostInfo.OS.Family {
-	case "redhat":
-		packages, err = listRPMPackages()
-		if err != nil {
-			fmt.Println(err)
+		// lister may return a partial result alongside an error (e.g. one
+		// package manager's lister failed but others succeeded); report
+		// the error but keep going with whatever was gathered.
+		fmt.Fprintln(os.Stderr, err)
+		if len(packages) == 0 {
 			os.Exit(1)
 		}
-	case "debian":
-		packages, err = listDebPackages()
+	}
+
+	if *whereExpr != "" {
+		clauses, err := parseWhere(*whereExpr)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-	case "darwin":
-		packages, err = listBrewPackages()
+		packages, err = filterPackages(packages, clauses)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-	default:
-		fmt.Println("I don't know how to get pacakges on OS family %s", hostInfo.OS.Family)
-		os.Exit(1)
-		return
 	}
 
 	for _, pkg := range packages {
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pacmanLocalDir holds one directory per installed package, each with a
+// "desc" file of %FIELD%\nvalue\n\n blocks.
+const pacmanLocalDir = "/var/lib/pacman/local"
+
+// listPacmanPackages enumerates installed packages from pacman's local
+// database.
+func listPacmanPackages() ([]Package, error) {
+	return listPacmanPackagesAt(pacmanLocalDir)
+}
+
+// listPacmanPackagesAt enumerates installed packages from a pacman local
+// database rooted at localDir, broken out from listPacmanPackages so tests
+// can point it at a fixture tree.
+func listPacmanPackagesAt(localDir string) ([]Package, error) {
+	entries, err := ioutil.ReadDir(localDir)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading directory %s: %v", localDir, err)
+	}
+
+	packages := []Package{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		pkg, err := parsePacmanDesc(path.Join(localDir, entry.Name(), "desc"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
+
+// parsePacmanDesc reads a single pacman "desc" file into a Package.
+func parsePacmanDesc(descPath string) (Package, error) {
+	file, err := os.Open(descPath)
+	if err != nil {
+		return Package{}, err
+	}
+	defer file.Close()
+
+	pkg := Package{}
+	field := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "%") && strings.HasSuffix(line, "%") {
+			field = strings.Trim(line, "%")
+			continue
+		}
+		if line == "" {
+			field = ""
+			continue
+		}
+		switch field {
+		case "NAME":
+			pkg.Name = line
+		case "VERSION":
+			// pacman stores pkgver-pkgrel together, e.g. "1.0-1".
+			if i := strings.LastIndex(line, "-"); i >= 0 {
+				pkg.Version = line[:i]
+				pkg.Release = line[i+1:]
+			} else {
+				pkg.Version = line
+			}
+		case "ARCH":
+			pkg.Arch = line
+		case "LICENSE":
+			pkg.License = line
+		case "DESC":
+			pkg.Summary = line
+		case "URL":
+			pkg.URL = line
+		case "INSTALLDATE":
+			if secs, err := strconv.ParseInt(line, 10, 64); err == nil {
+				pkg.InstallTime = time.Unix(secs, 0)
+			}
+		case "SIZE":
+			if size, err := strconv.ParseUint(line, 10, 64); err == nil {
+				pkg.Size = size
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Package{}, fmt.Errorf("Error scanning file %s: %v", descPath, err)
+	}
+	return pkg, nil
+}
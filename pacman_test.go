@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestParsePacmanDesc(t *testing.T) {
+	pkg, err := parsePacmanDesc("testdata/pacman/local/openssl-1.1.1n-1/desc")
+	if err != nil {
+		t.Fatalf("parsePacmanDesc: %v", err)
+	}
+
+	if pkg.Name != "openssl" {
+		t.Errorf("Name = %q, want %q", pkg.Name, "openssl")
+	}
+	if pkg.Version != "1.1.1n" {
+		t.Errorf("Version = %q, want %q", pkg.Version, "1.1.1n")
+	}
+	if pkg.Release != "1" {
+		t.Errorf("Release = %q, want %q", pkg.Release, "1")
+	}
+	if pkg.Arch != "x86_64" {
+		t.Errorf("Arch = %q, want %q", pkg.Arch, "x86_64")
+	}
+	if pkg.License != "custom" {
+		t.Errorf("License = %q, want %q", pkg.License, "custom")
+	}
+	if pkg.URL != "https://www.openssl.org" {
+		t.Errorf("URL = %q, want %q", pkg.URL, "https://www.openssl.org")
+	}
+	if pkg.Size != 7654321 {
+		t.Errorf("Size = %d, want %d", pkg.Size, 7654321)
+	}
+	if pkg.InstallTime.Unix() != 1646000000 {
+		t.Errorf("InstallTime = %v, want unix 1646000000", pkg.InstallTime)
+	}
+}
+
+func TestListPacmanPackagesAt(t *testing.T) {
+	packages, err := listPacmanPackagesAt("testdata/pacman/local")
+	if err != nil {
+		t.Fatalf("listPacmanPackagesAt: %v", err)
+	}
+	if len(packages) != 2 {
+		t.Fatalf("got %d packages, want 2", len(packages))
+	}
+
+	byName := map[string]Package{}
+	for _, pkg := range packages {
+		byName[pkg.Name] = pkg
+	}
+	if _, ok := byName["openssl"]; !ok {
+		t.Errorf("missing openssl package, got %+v", packages)
+	}
+	if bash, ok := byName["bash"]; !ok {
+		t.Errorf("missing bash package, got %+v", packages)
+	} else if bash.Version != "5.1.016" {
+		t.Errorf("bash Version = %q, want %q", bash.Version, "5.1.016")
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// portageDBDir holds one directory per installed package under
+// <category>/<name-version>/, each with plain-text metadata files such as
+// PF, SLOT, LICENSE and DESCRIPTION.
+const portageDBDir = "/var/db/pkg"
+
+// portageNameVersionRE splits a portage package atom like "nano-6.4" or
+// "nano-6.4-r1" into its name and version.
+var portageNameVersionRE = regexp.MustCompile(`^(.+)-(\d[\w.]*(?:-r\d+)?)$`)
+
+func splitPortageNameVersion(pf string) (name, version string) {
+	if m := portageNameVersionRE.FindStringSubmatch(pf); m != nil {
+		return m[1], m[2]
+	}
+	return pf, ""
+}
+
+// listPortagePackages enumerates installed packages from Gentoo's Portage
+// database.
+func listPortagePackages() ([]Package, error) {
+	return listPortagePackagesAt(portageDBDir)
+}
+
+// listPortagePackagesAt enumerates installed packages from a Portage
+// database rooted at dbDir, broken out from listPortagePackages so tests
+// can point it at a fixture tree.
+func listPortagePackagesAt(dbDir string) ([]Package, error) {
+	categories, err := ioutil.ReadDir(dbDir)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading directory %s: %v", dbDir, err)
+	}
+
+	packages := []Package{}
+	for _, category := range categories {
+		if !category.IsDir() {
+			continue
+		}
+		categoryPath := filepath.Join(dbDir, category.Name())
+		pkgDirs, err := ioutil.ReadDir(categoryPath)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading directory %s: %v", categoryPath, err)
+		}
+		for _, pkgDir := range pkgDirs {
+			if !pkgDir.IsDir() {
+				continue
+			}
+			packages = append(packages, parsePortagePackage(filepath.Join(categoryPath, pkgDir.Name())))
+		}
+	}
+	return packages, nil
+}
+
+// readPortageField reads one of Portage's single-value metadata files,
+// returning "" if it's missing (not every package sets every field).
+func readPortageField(dir, name string) string {
+	data, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func parsePortagePackage(dir string) Package {
+	pf := readPortageField(dir, "PF")
+	if pf == "" {
+		pf = filepath.Base(dir)
+	}
+	name, version := splitPortageNameVersion(pf)
+
+	pkg := Package{
+		Name:    name,
+		Version: version,
+		Release: readPortageField(dir, "SLOT"),
+		License: readPortageField(dir, "LICENSE"),
+		Summary: readPortageField(dir, "DESCRIPTION"),
+		URL:     readPortageField(dir, "HOMEPAGE"),
+	}
+	if size, err := strconv.ParseUint(readPortageField(dir, "SIZE"), 10, 64); err == nil {
+		pkg.Size = size
+	}
+	if secs, err := strconv.ParseInt(readPortageField(dir, "BUILD_TIME"), 10, 64); err == nil {
+		pkg.InstallTime = time.Unix(secs, 0)
+	}
+	return pkg
+}
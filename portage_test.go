@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+func TestSplitPortageNameVersion(t *testing.T) {
+	tests := []struct {
+		pf          string
+		wantName    string
+		wantVersion string
+	}{
+		{"nano-6.4", "nano", "6.4"},
+		{"sed-4.8", "sed", "4.8"},
+		{"python-3.11.2-r1", "python", "3.11.2-r1"},
+	}
+	for _, tt := range tests {
+		name, version := splitPortageNameVersion(tt.pf)
+		if name != tt.wantName || version != tt.wantVersion {
+			t.Errorf("splitPortageNameVersion(%q) = (%q, %q), want (%q, %q)", tt.pf, name, version, tt.wantName, tt.wantVersion)
+		}
+	}
+}
+
+func TestParsePortagePackage(t *testing.T) {
+	pkg := parsePortagePackage("testdata/portage/app-editors/nano-6.4")
+
+	if pkg.Name != "nano" {
+		t.Errorf("Name = %q, want %q", pkg.Name, "nano")
+	}
+	if pkg.Version != "6.4" {
+		t.Errorf("Version = %q, want %q", pkg.Version, "6.4")
+	}
+	if pkg.Release != "0" {
+		t.Errorf("Release (SLOT) = %q, want %q", pkg.Release, "0")
+	}
+	if pkg.License != "GPL-3" {
+		t.Errorf("License = %q, want %q", pkg.License, "GPL-3")
+	}
+	if pkg.URL != "https://www.nano-editor.org/" {
+		t.Errorf("URL = %q, want %q", pkg.URL, "https://www.nano-editor.org/")
+	}
+	if pkg.Size != 761234 {
+		t.Errorf("Size = %d, want %d", pkg.Size, 761234)
+	}
+	if pkg.InstallTime.Unix() != 1646001000 {
+		t.Errorf("InstallTime = %v, want unix 1646001000", pkg.InstallTime)
+	}
+}
+
+func TestListPortagePackagesAt(t *testing.T) {
+	packages, err := listPortagePackagesAt("testdata/portage")
+	if err != nil {
+		t.Fatalf("listPortagePackagesAt: %v", err)
+	}
+	if len(packages) != 2 {
+		t.Fatalf("got %d packages, want 2", len(packages))
+	}
+}
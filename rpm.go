@@ -0,0 +1,72 @@
+//go:build linux && cgo
+
+package main
+
+/*
+#cgo LDFLAGS: -lrpm -lrpmio
+#include <stdlib.h>
+#include <rpm/rpmlib.h>
+#include <rpm/rpmts.h>
+#include <rpm/rpmdb.h>
+#include <rpm/header.h>
+#include <rpm/rpmtag.h>
+
+static const char *hdr_string(Header h, rpmTag tag) {
+	return headerGetString(h, tag);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"time"
+)
+
+// rpmSupported is true when this binary was built with librpm bindings
+// available, letting the worker loop in rpm_worker.go tell "not supported
+// here" apart from "crashed repeatedly".
+const rpmSupported = true
+
+// listRPMPackagesCgo enumerates the RPM database (Berkeley DB or the sqlite
+// backend used by newer Fedora) via librpm. It must only be called from the
+// rpm worker process: librpm is known to crash on corrupt databases, and a
+// crash here takes down whatever process called it.
+func listRPMPackagesCgo() ([]Package, error) {
+	if C.rpmReadConfigFiles(nil, nil) != 0 {
+		return nil, fmt.Errorf("Error reading rpm config files")
+	}
+	defer C.rpmFreeRpmrc()
+
+	ts := C.rpmtsCreate()
+	if ts == nil {
+		return nil, fmt.Errorf("Error creating rpm transaction set")
+	}
+	defer C.rpmtsFree(ts)
+
+	mi := C.rpmtsInitIterator(ts, C.RPMDBI_PACKAGES, nil, 0)
+	if mi == nil {
+		return nil, fmt.Errorf("Error initializing rpm database iterator")
+	}
+	defer C.rpmdbFreeIterator(mi)
+
+	packages := []Package{}
+	for {
+		h := C.rpmdbNextIterator(mi)
+		if h == nil {
+			break
+		}
+		pkg := Package{
+			Name:    C.GoString(C.hdr_string(h, C.RPMTAG_NAME)),
+			Version: C.GoString(C.hdr_string(h, C.RPMTAG_VERSION)),
+			Release: C.GoString(C.hdr_string(h, C.RPMTAG_RELEASE)),
+			Arch:    C.GoString(C.hdr_string(h, C.RPMTAG_ARCH)),
+			License: C.GoString(C.hdr_string(h, C.RPMTAG_LICENSE)),
+			Summary: C.GoString(C.hdr_string(h, C.RPMTAG_SUMMARY)),
+			URL:     C.GoString(C.hdr_string(h, C.RPMTAG_URL)),
+			Size:    uint64(C.headerGetNumber(h, C.RPMTAG_SIZE)),
+		}
+		pkg.InstallTime = time.Unix(int64(C.headerGetNumber(h, C.RPMTAG_INSTALLTIME)), 0)
+		packages = append(packages, pkg)
+	}
+	return packages, nil
+}
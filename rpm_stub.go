@@ -0,0 +1,14 @@
+//go:build !(linux && cgo)
+
+package main
+
+import "fmt"
+
+// rpmSupported is false on this build: see rpm.go.
+const rpmSupported = false
+
+// listRPMPackagesCgo is only implemented for linux builds with cgo enabled,
+// since it binds directly to librpm.
+func listRPMPackagesCgo() ([]Package, error) {
+	return nil, fmt.Errorf("RPM package listing requires a linux build with cgo enabled")
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// rpmWorkerFlag re-execs this binary to run the librpm enumeration in a
+// throwaway child process, matching the rpmWorkerEnv child below.
+const rpmWorkerFlag = "--rpm-worker"
+
+// rpmWorkerEnv tells a re-exec'd child that it should read the rpm database
+// in-process rather than spawning another worker.
+const rpmWorkerEnv = "LISTPACKAGES_RPM_WORKER=1"
+
+const maxRPMWorkerRestarts = 3
+
+// rpmWorkerSpawn starts one attempt at the worker, returning a reader over
+// its newline-delimited JSON Package stream and a func to wait for it to
+// exit. It's a seam so listRPMPackagesWithSpawner can be tested without a
+// real librpm build.
+type rpmWorkerSpawn func() (stdout io.Reader, wait func() error, err error)
+
+// listRPMPackages reads the RPM database by re-exec'ing this binary with
+// --rpm-worker and streaming back JSON Package records over stdout. librpm
+// has historically crashed on corrupt databases; running it in a child we
+// can restart means a crash there doesn't take down the caller.
+func listRPMPackages() ([]Package, error) {
+	if os.Getenv("LISTPACKAGES_RPM_WORKER") != "" || !rpmSupported {
+		// On an unsupported build (no cgo, or not linux) listRPMPackagesCgo
+		// fails the same static "unsupported" way every time; report that
+		// once instead of retrying it and claiming the worker crashed.
+		return listRPMPackagesCgo()
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("Error finding own executable: %v", err)
+	}
+
+	return listRPMPackagesWithSpawner(func() (io.Reader, func() error, error) {
+		cmd := exec.Command(self, rpmWorkerFlag)
+		cmd.Env = append(os.Environ(), rpmWorkerEnv)
+		cmd.Stderr = os.Stderr
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error creating stdout pipe for rpm worker: %v", err)
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, nil, fmt.Errorf("Error starting rpm worker: %v", err)
+		}
+		return stdout, cmd.Wait, nil
+	})
+}
+
+// listRPMPackagesWithSpawner runs the restart/dedupe loop against whatever
+// spawn produces. Broken out from listRPMPackages so the loop can be
+// exercised in tests with a fake worker instead of a real librpm build.
+func listRPMPackagesWithSpawner(spawn rpmWorkerSpawn) ([]Package, error) {
+	// seen persists across restart attempts so a worker that crashes partway
+	// through and gets re-run doesn't re-emit packages already collected;
+	// it must not collapse distinct packages within a single successful
+	// read (e.g. two kernel versions share Name/Version/Arch but differ in
+	// Release).
+	seen := map[string]bool{}
+	packages := []Package{}
+	var lastErr error
+	for attempt := 0; attempt <= maxRPMWorkerRestarts; attempt++ {
+		stdout, wait, err := spawn()
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var pkg Package
+			if err := json.Unmarshal(scanner.Bytes(), &pkg); err != nil {
+				continue
+			}
+			key := pkg.Name + "/" + pkg.Version + "/" + pkg.Release + "/" + pkg.Arch
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			packages = append(packages, pkg)
+		}
+
+		lastErr = wait()
+		if lastErr == nil {
+			return packages, nil
+		}
+		fmt.Fprintf(os.Stderr, "rpm worker exited (attempt %d/%d): %v, restarting\n", attempt+1, maxRPMWorkerRestarts+1, lastErr)
+	}
+	return packages, fmt.Errorf("rpm worker crashed %d times in a row: %v", maxRPMWorkerRestarts+1, lastErr)
+}
+
+// runRPMWorker is the entry point used when this binary is re-exec'd with
+// --rpm-worker. It reads the rpm database in-process and streams one JSON
+// Package per line to stdout so the parent can recover if we crash.
+func runRPMWorker() {
+	packages, err := listRPMPackagesCgo()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, pkg := range packages {
+		bt, _ := json.Marshal(pkg)
+		fmt.Println(string(bt))
+	}
+}
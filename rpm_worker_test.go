@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeRPMAttempt is one simulated worker run: the JSON lines it streams on
+// stdout, and the error wait() should return (nil for a clean exit).
+type fakeRPMAttempt struct {
+	lines []string
+	err   error
+}
+
+func fakeRPMSpawner(attempts []fakeRPMAttempt) rpmWorkerSpawn {
+	i := 0
+	return func() (io.Reader, func() error, error) {
+		attempt := attempts[i]
+		i++
+		return strings.NewReader(strings.Join(attempt.lines, "\n")), func() error {
+			return attempt.err
+		}, nil
+	}
+}
+
+func TestListRPMPackagesWithSpawnerDedupesAcrossRestart(t *testing.T) {
+	spawner := fakeRPMSpawner([]fakeRPMAttempt{
+		{
+			lines: []string{
+				`{"name":"bash","version":"5.1","release":"1","arch":"x86_64"}`,
+			},
+			err: fmt.Errorf("worker crashed"),
+		},
+		{
+			lines: []string{
+				`{"name":"bash","version":"5.1","release":"1","arch":"x86_64"}`,
+				`{"name":"openssl","version":"1.1.1n","release":"1","arch":"x86_64"}`,
+			},
+			err: nil,
+		},
+	})
+
+	packages, err := listRPMPackagesWithSpawner(spawner)
+	if err != nil {
+		t.Fatalf("listRPMPackagesWithSpawner: %v", err)
+	}
+	if len(packages) != 2 {
+		t.Fatalf("got %d packages, want 2 (bash dedup'd across restart): %+v", len(packages), packages)
+	}
+}
+
+func TestListRPMPackagesWithSpawnerKeepsCoexistingReleases(t *testing.T) {
+	spawner := fakeRPMSpawner([]fakeRPMAttempt{
+		{
+			lines: []string{
+				`{"name":"kernel","version":"4.18.0","release":"305.3.1.el8_4","arch":"x86_64"}`,
+				`{"name":"kernel","version":"4.18.0","release":"305.7.1.el8_4","arch":"x86_64"}`,
+			},
+			err: nil,
+		},
+	})
+
+	packages, err := listRPMPackagesWithSpawner(spawner)
+	if err != nil {
+		t.Fatalf("listRPMPackagesWithSpawner: %v", err)
+	}
+	if len(packages) != 2 {
+		t.Fatalf("got %d packages, want 2 distinct kernel releases: %+v", len(packages), packages)
+	}
+}
+
+func TestListRPMPackagesWithSpawnerExhaustsRestarts(t *testing.T) {
+	attempts := make([]fakeRPMAttempt, maxRPMWorkerRestarts+1)
+	for i := range attempts {
+		attempts[i] = fakeRPMAttempt{err: fmt.Errorf("boom")}
+	}
+
+	_, err := listRPMPackagesWithSpawner(fakeRPMSpawner(attempts))
+	if err == nil {
+		t.Fatal("expected an error after exhausting restarts, got nil")
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// packageTriggerPaths are watched with fsnotify so that changes are picked
+// up promptly instead of purely on the --watch interval.
+var packageTriggerPaths = []string{
+	"/var/lib/dpkg/status",
+	"/var/lib/rpm/Packages",
+	"/usr/local/Cellar",
+}
+
+// PackageEvent is emitted whenever the Watcher notices a package was
+// installed, removed, or updated between two snapshots.
+type PackageEvent struct {
+	Action    string    `json:"action"` // "installed", "removed" or "updated"
+	Package   Package   `json:"package"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Watcher periodically re-runs a Lister, diffs the result against the
+// previous run (persisted to StatePath), and emits PackageEvents for
+// anything that changed. It is the audit-style counterpart to the one-shot
+// snapshot main() prints by default.
+type Watcher struct {
+	Interval  time.Duration
+	StatePath string
+	Lister    func() ([]Package, error)
+}
+
+// NewWatcher builds a Watcher that polls lister every interval and persists
+// its snapshots to statePath.
+func NewWatcher(interval time.Duration, statePath string, lister func() ([]Package, error)) *Watcher {
+	return &Watcher{
+		Interval:  interval,
+		StatePath: statePath,
+		Lister:    lister,
+	}
+}
+
+// Run polls forever, printing one JSON PackageEvent per line to stdout for
+// every change it detects. It only returns on an unrecoverable error.
+func (w *Watcher) Run() error {
+	previous, hadState, err := w.loadState()
+	if err != nil {
+		return fmt.Errorf("Error loading previous state from '%s': %v", w.StatePath, err)
+	}
+	// On the very first run (no persisted state yet) the whole inventory
+	// would otherwise be reported as "installed", which is just noise for
+	// an audit source. Establish a baseline instead.
+	baseline := !hadState
+
+	trigger := make(chan struct{}, 1)
+	if watcher, err := fsnotify.NewWatcher(); err == nil {
+		defer watcher.Close()
+		for _, p := range packageTriggerPaths {
+			// Best effort: a missing path just means that package manager
+			// isn't present on this host.
+			_ = watcher.Add(p)
+		}
+		go func() {
+			for range watcher.Events {
+				select {
+				case trigger <- struct{}{}:
+				default:
+				}
+			}
+		}()
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: fsnotify unavailable, falling back to interval-only watching: %v\n", err)
+	}
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		current, err := w.Lister()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing packages: %v\n", err)
+		}
+		// current may be a partial result alongside a non-nil err (e.g. one
+		// package manager's lister failed but others succeeded); still
+		// diff and persist whatever was gathered instead of discarding it.
+		if len(current) > 0 || err == nil {
+			if !baseline {
+				for _, event := range diffPackages(previous, current) {
+					event.Timestamp = time.Now()
+					bt, _ := json.Marshal(event)
+					fmt.Println(string(bt))
+				}
+			}
+			baseline = false
+			if err := w.saveState(current); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving state to '%s': %v\n", w.StatePath, err)
+			}
+			previous = current
+		}
+
+		select {
+		case <-ticker.C:
+		case <-trigger:
+		}
+	}
+}
+
+// loadState reads the persisted snapshot, reporting whether one existed at
+// all (as opposed to existing but being empty) so Run can tell a first-ever
+// run from a host that's genuinely down to zero packages.
+func (w *Watcher) loadState() (packages []Package, existed bool, err error) {
+	data, err := ioutil.ReadFile(w.StatePath)
+	if os.IsNotExist(err) {
+		return []Package{}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if err := json.Unmarshal(data, &packages); err != nil {
+		return nil, false, err
+	}
+	return packages, true, nil
+}
+
+func (w *Watcher) saveState(packages []Package) error {
+	data, err := json.Marshal(packages)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(w.StatePath, data, 0644)
+}
+
+// nameArchKey groups packages that share a Name+Arch, e.g. the several
+// kernel versions or Homebrew kegs that can be installed side by side.
+func nameArchKey(pkg Package) string {
+	return pkg.Name + "/" + pkg.Arch
+}
+
+// groupByVersion buckets packages by Name+Arch, then by exact Version
+// within that group, so diffPackages can tell "a second version was
+// installed alongside the first" apart from "the version changed".
+func groupByVersion(pkgs []Package) map[string]map[string][]Package {
+	groups := make(map[string]map[string][]Package)
+	for _, pkg := range pkgs {
+		key := nameArchKey(pkg)
+		if groups[key] == nil {
+			groups[key] = make(map[string][]Package)
+		}
+		groups[key][pkg.Version] = append(groups[key][pkg.Version], pkg)
+	}
+	return groups
+}
+
+// diffPackages compares two snapshots and returns installed/removed/updated
+// events, per Name+Arch group. Versions present in current but not previous
+// are "installed" unless a same-group version disappeared in the same
+// diff, in which case they're paired up as "updated"; versions present in
+// previous but not current are "removed".
+func diffPackages(previous, current []Package) []PackageEvent {
+	prevGroups := groupByVersion(previous)
+	currGroups := groupByVersion(current)
+
+	keys := make(map[string]bool, len(prevGroups)+len(currGroups))
+	for key := range prevGroups {
+		keys[key] = true
+	}
+	for key := range currGroups {
+		keys[key] = true
+	}
+
+	events := []PackageEvent{}
+	for key := range keys {
+		prevVersions := prevGroups[key]
+		currVersions := currGroups[key]
+
+		var added, removed []Package
+		for version, pkgs := range currVersions {
+			if _, ok := prevVersions[version]; !ok {
+				added = append(added, pkgs...)
+			}
+		}
+		for version, pkgs := range prevVersions {
+			if _, ok := currVersions[version]; !ok {
+				removed = append(removed, pkgs...)
+			}
+		}
+
+		// Map iteration order is randomized, so added/removed must be sorted
+		// before pairing; otherwise which removed version gets folded into
+		// an "updated" event vs. reported as "removed" would vary run to
+		// run for the exact same input.
+		sort.Slice(added, func(i, j int) bool { return added[i].Version < added[j].Version })
+		sort.Slice(removed, func(i, j int) bool { return removed[i].Version < removed[j].Version })
+
+		paired := len(added)
+		if len(removed) < paired {
+			paired = len(removed)
+		}
+		for i := 0; i < paired; i++ {
+			events = append(events, PackageEvent{Action: "updated", Package: added[i]})
+		}
+		for _, pkg := range added[paired:] {
+			events = append(events, PackageEvent{Action: "installed", Package: pkg})
+		}
+		for _, pkg := range removed[paired:] {
+			events = append(events, PackageEvent{Action: "removed", Package: pkg})
+		}
+	}
+
+	return events
+}
@@ -0,0 +1,80 @@
+package main
+
+import "testing"
+
+func countActions(events []PackageEvent, action string) int {
+	n := 0
+	for _, e := range events {
+		if e.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDiffPackagesUpdate(t *testing.T) {
+	previous := []Package{{Name: "bash", Version: "5.0", Arch: "amd64"}}
+	current := []Package{{Name: "bash", Version: "5.1", Arch: "amd64"}}
+
+	events := diffPackages(previous, current)
+	if len(events) != 1 || events[0].Action != "updated" || events[0].Package.Version != "5.1" {
+		t.Fatalf("got %+v, want a single updated event for version 5.1", events)
+	}
+}
+
+func TestDiffPackagesSecondVersionInstalledAlongsideFirst(t *testing.T) {
+	previous := []Package{{Name: "linux-image", Version: "5.15", Arch: "amd64"}}
+	current := []Package{
+		{Name: "linux-image", Version: "5.15", Arch: "amd64"},
+		{Name: "linux-image", Version: "6.1", Arch: "amd64"},
+	}
+
+	events := diffPackages(previous, current)
+	if len(events) != 1 || countActions(events, "installed") != 1 {
+		t.Fatalf("got %+v, want a single installed event for the new kernel version, not an update", events)
+	}
+}
+
+func TestDiffPackagesOneOfTwoVersionsRemoved(t *testing.T) {
+	previous := []Package{
+		{Name: "linux-image", Version: "5.15", Arch: "amd64"},
+		{Name: "linux-image", Version: "6.1", Arch: "amd64"},
+	}
+	current := []Package{{Name: "linux-image", Version: "6.1", Arch: "amd64"}}
+
+	events := diffPackages(previous, current)
+	if len(events) != 1 || countActions(events, "removed") != 1 {
+		t.Fatalf("got %+v, want a single removed event for the dropped kernel version", events)
+	}
+}
+
+func TestDiffPackagesTwoVersionsCollapseToOneIsDeterministic(t *testing.T) {
+	previous := []Package{
+		{Name: "linux-image", Version: "4.17", Arch: "amd64"},
+		{Name: "linux-image", Version: "4.18", Arch: "amd64"},
+	}
+	current := []Package{{Name: "linux-image", Version: "4.19", Arch: "amd64"}}
+
+	var first []PackageEvent
+	for i := 0; i < 20; i++ {
+		events := diffPackages(previous, current)
+		if len(events) != 2 || countActions(events, "updated") != 1 || countActions(events, "removed") != 1 {
+			t.Fatalf("run %d: got %+v, want one updated and one removed event", i, events)
+		}
+		if first == nil {
+			first = events
+			continue
+		}
+		if events[0].Action != first[0].Action || events[0].Package.Version != first[0].Package.Version ||
+			events[1].Action != first[1].Action || events[1].Package.Version != first[1].Package.Version {
+			t.Fatalf("run %d: got %+v, want same outcome as first run %+v (non-deterministic)", i, events, first)
+		}
+	}
+}
+
+func TestDiffPackagesNoChange(t *testing.T) {
+	packages := []Package{{Name: "bash", Version: "5.1", Arch: "amd64"}}
+	if events := diffPackages(packages, packages); len(events) != 0 {
+		t.Fatalf("got %+v, want no events for an unchanged snapshot", events)
+	}
+}